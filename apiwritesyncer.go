@@ -0,0 +1,419 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	logging "cloud.google.com/go/logging/apiv2"
+	"cloud.google.com/go/logging/apiv2/loggingpb"
+	"go.uber.org/zap/zapcore"
+	monitoredrespb "google.golang.org/genproto/googleapis/api/monitoredres"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// OverflowPolicy decides what apiWriteSyncer does when its in-memory queue is full.
+type OverflowPolicy int
+
+const (
+	// OverflowBlock blocks Write until the queue has room. This is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest queued entry to make room for the new one.
+	OverflowDropOldest
+	// OverflowDropNewest discards the entry that would have been enqueued.
+	OverflowDropNewest
+)
+
+const (
+	defaultMaxBatchBytes = 1 << 20 // WriteLogEntries caps requests at 10MiB; 1MiB keeps each request small.
+	defaultFlushInterval = 5 * time.Second
+	defaultQueueSize     = 10000
+)
+
+// APIOption configures NewAPIWriteSyncer.
+type APIOption interface {
+	apply(*apiWriteSyncer)
+}
+
+// apiOptionFunc wraps a func so it satisfies the APIOption interface.
+type apiOptionFunc func(*apiWriteSyncer)
+
+func (f apiOptionFunc) apply(w *apiWriteSyncer) {
+	f(w)
+}
+
+// WithMaxBatchBytes overrides the default 1MiB batch size limit.
+func WithMaxBatchBytes(n int) APIOption {
+	return apiOptionFunc(func(w *apiWriteSyncer) {
+		w.maxBatchBytes = n
+	})
+}
+
+// WithFlushInterval overrides the default 5s batch flush interval.
+func WithFlushInterval(d time.Duration) APIOption {
+	return apiOptionFunc(func(w *apiWriteSyncer) {
+		w.flushInterval = d
+	})
+}
+
+// WithQueueSize overrides the default in-memory queue bound of 10000 entries.
+func WithQueueSize(n int) APIOption {
+	return apiOptionFunc(func(w *apiWriteSyncer) {
+		w.queueSize = n
+	})
+}
+
+// WithOverflowPolicy overrides the default OverflowBlock behavior for a full queue.
+func WithOverflowPolicy(p OverflowPolicy) APIOption {
+	return apiOptionFunc(func(w *apiWriteSyncer) {
+		w.overflow = p
+	})
+}
+
+// WithResource sets the monitored resource reported on every LogEntry's request.
+func WithResource(resource string) APIOption {
+	return apiOptionFunc(func(w *apiWriteSyncer) {
+		w.resource = resource
+	})
+}
+
+// apiWriteSyncer streams entries straight to the Cloud Logging API via WriteLogEntries,
+// batching and retrying transient failures, instead of going through an intermediary
+// agent such as Fluent Bit.
+//
+// The queue is flushed every flushInterval, on Sync and on Close; each flush splits it
+// into requests of at most maxBatchBytes. Reaching maxBatchBytes does not itself trigger
+// a flush.
+type apiWriteSyncer struct {
+	ctx      context.Context
+	client   *logging.Client
+	logName  string
+	resource string
+
+	maxBatchBytes int
+	flushInterval time.Duration
+	queueSize     int
+	overflow      OverflowPolicy
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  []*loggingpb.LogEntry
+	closed bool
+	flushc chan chan error
+	closec chan struct{}
+	wg     sync.WaitGroup
+}
+
+var _ zapcore.WriteSyncer = (*apiWriteSyncer)(nil)
+var _ entryWriter = (*apiWriteSyncer)(nil)
+
+// NewAPIWriteSyncer returns a zapcore.WriteSyncer that writes entries directly to Cloud
+// Logging via client.WriteLogEntries on logName (e.g.
+// "projects/PROJECT_ID/logs/LOG_ID"), instead of relying on a local agent to forward
+// stdout.
+//
+// Core prefers apiWriteSyncer's entryWriter fast path, building each LogEntry from the
+// zapcore.Entry/Fields directly rather than round-tripping through JSON.
+func NewAPIWriteSyncer(ctx context.Context, client *logging.Client, logName string, opts ...APIOption) zapcore.WriteSyncer {
+	w := &apiWriteSyncer{
+		ctx:           ctx,
+		client:        client,
+		logName:       logName,
+		maxBatchBytes: defaultMaxBatchBytes,
+		flushInterval: defaultFlushInterval,
+		queueSize:     defaultQueueSize,
+		flushc:        make(chan chan error),
+		closec:        make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt.apply(w)
+	}
+	w.cond = sync.NewCond(&w.mu)
+
+	w.wg.Add(1)
+	go w.loop()
+
+	return w
+}
+
+// WriteEntry implements entryWriter, building a LogEntry from ent/fields and enqueuing it
+// for the next batch.
+func (w *apiWriteSyncer) WriteEntry(ent zapcore.Entry, fields []zapcore.Field) error {
+	return w.enqueue(entryFromFields(ent, fields, w.logName, w.resource))
+}
+
+// Write implements zapcore.WriteSyncer for callers that did not go through Core's
+// entryWriter fast path (e.g. a hand-built zapcore.NewCore(someEncoder, apiWriteSyncer,
+// ...) wiring a JSON encoder directly to this WriteSyncer). p is decoded as the JSON object
+// a zapcore.JSONEncoder would have produced and enqueued as that LogEntry's JsonPayload, so
+// entries are not silently dropped when WriteEntry's fast path isn't in use.
+func (w *apiWriteSyncer) Write(p []byte) (int, error) {
+	var payload structpb.Struct
+	if err := protojson.Unmarshal(p, &payload); err != nil {
+		return 0, fmt.Errorf("zapcl: decode log entry: %w", err)
+	}
+
+	entry := &loggingpb.LogEntry{
+		LogName: w.logName,
+		Payload: &loggingpb.LogEntry_JsonPayload{JsonPayload: &payload},
+	}
+	if w.resource != "" {
+		entry.Resource = &monitoredrespb.MonitoredResource{Type: w.resource}
+	}
+
+	if err := w.enqueue(entry); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Sync flushes any queued entries, blocking until the flush completes or ctx is done.
+func (w *apiWriteSyncer) Sync() error {
+	errc := make(chan error, 1)
+	select {
+	case w.flushc <- errc:
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case <-w.closec:
+		return fmt.Errorf("zapcl: api write syncer is closed")
+	}
+
+	select {
+	case err := <-errc:
+		return err
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// Close stops the background flush loop, flushing any remaining entries first.
+func (w *apiWriteSyncer) Close() error {
+	err := w.Sync()
+
+	w.mu.Lock()
+	w.closed = true
+	w.cond.Broadcast()
+	w.mu.Unlock()
+
+	close(w.closec)
+	w.wg.Wait()
+
+	return err
+}
+
+// enqueue appends entry to the queue, applying overflow according to w.overflow when full.
+func (w *apiWriteSyncer) enqueue(entry *loggingpb.LogEntry) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for len(w.queue) >= w.queueSize && w.overflow == OverflowBlock && !w.closed {
+		w.cond.Wait()
+	}
+
+	switch {
+	case len(w.queue) < w.queueSize:
+		w.queue = append(w.queue, entry)
+	case w.overflow == OverflowDropOldest:
+		w.queue = append(w.queue[1:], entry)
+	case w.overflow == OverflowDropNewest:
+		return nil
+	default:
+		w.queue = append(w.queue, entry)
+	}
+
+	w.cond.Signal()
+
+	return nil
+}
+
+// loop owns the background batching/flush goroutine for the lifetime of the
+// apiWriteSyncer.
+func (w *apiWriteSyncer) loop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			w.flush()
+		case errc := <-w.flushc:
+			errc <- w.flush()
+		case <-w.closec:
+			w.flush() //nolint:errcheck
+			return
+		}
+	}
+}
+
+// flush drains the queue in maxBatchBytes-sized batches and writes each via
+// writeWithRetry.
+func (w *apiWriteSyncer) flush() error {
+	for {
+		batch := w.takeBatch()
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := w.writeWithRetry(batch); err != nil {
+			return err
+		}
+	}
+}
+
+// takeBatch removes and returns up to maxBatchBytes worth of entries from the queue.
+func (w *apiWriteSyncer) takeBatch() []*loggingpb.LogEntry {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var batch []*loggingpb.LogEntry
+	size := 0
+	for len(w.queue) > 0 {
+		n := proto.Size(w.queue[0])
+		if len(batch) > 0 && size+n > w.maxBatchBytes {
+			break
+		}
+		batch = append(batch, w.queue[0])
+		w.queue = w.queue[1:]
+		size += n
+	}
+
+	w.cond.Signal()
+
+	return batch
+}
+
+// writeWithRetry calls WriteLogEntries, retrying with exponential backoff on Unavailable
+// and ResourceExhausted errors.
+func (w *apiWriteSyncer) writeWithRetry(entries []*loggingpb.LogEntry) error {
+	req := &loggingpb.WriteLogEntriesRequest{
+		LogName: w.logName,
+		Entries: entries,
+	}
+	if w.resource != "" {
+		req.Resource = &monitoredrespb.MonitoredResource{Type: w.resource}
+	}
+
+	backoff := 100 * time.Millisecond
+	const maxBackoff = 30 * time.Second
+
+	for attempt := 0; ; attempt++ {
+		_, err := w.client.WriteLogEntries(w.ctx, req)
+		if err == nil {
+			return nil
+		}
+		if !isRetryableStatus(err) {
+			return fmt.Errorf("zapcl: write log entries: %w", err)
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// isRetryableStatus reports whether err is a gRPC status that WriteLogEntries can
+// reasonably be retried for.
+func isRetryableStatus(err error) bool {
+	s, ok := status.FromError(err)
+	if !ok {
+		return false
+	}
+
+	switch s.Code() {
+	case codes.Unavailable, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// entryFromFields builds a LogEntry directly from a zapcore.Entry and its Fields, reusing
+// the payload types this package already produces (operation, sourceLocation, HTTPPayload,
+// trace, resourcePayload) rather than re-parsing the JSON Core.enc would have produced.
+// fields is expected to include Core.persistFields (the resource/WithInitialFields/
+// logger.With(...) context normally baked into Core.enc) ahead of the log-site fields, so
+// this path sees the same context the JSON path does.
+//
+// resource, from WithResource, takes priority over the monitored resource
+// resourcePayload carries when both are present.
+func entryFromFields(ent zapcore.Entry, fields []zapcore.Field, logName, resource string) *loggingpb.LogEntry {
+	le := &loggingpb.LogEntry{
+		LogName:   logName,
+		Timestamp: timestamppb.New(ent.Time),
+		Severity:  levelToSeverity[ent.Level],
+	}
+	if resource != "" {
+		le.Resource = &monitoredrespb.MonitoredResource{Type: resource}
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	enc.AddString("message", ent.Message)
+	if ent.LoggerName != "" {
+		enc.AddString("logger", ent.LoggerName)
+	}
+	if ent.Caller.Defined {
+		enc.AddString("caller", ent.Caller.TrimmedPath())
+	}
+
+	for _, f := range fields {
+		// FilterEncoder wraps nested ObjectMarshaler fields (HTTPPayload, resourcePayload,
+		// ...) in filteredMarshaler for the JSON path; unwrap it here purely to recognize
+		// the special-cased types below. Note this does not apply WithFieldFilter
+		// redaction to the HttpRequest proto, since that proto is copied directly from
+		// HTTPPayload rather than built through its (filter-aware) MarshalLogObject.
+		iface := f.Interface
+		if fm, ok := iface.(filteredMarshaler); ok {
+			iface = fm.ObjectMarshaler
+		}
+
+		switch v := iface.(type) {
+		case *sourceLocation:
+			le.SourceLocation = v.LogEntrySourceLocation
+			continue
+		case *operation:
+			le.Operation = v.LogEntryOperation
+			continue
+		case *HTTPPayload:
+			le.HttpRequest = v.HttpRequest
+			continue
+		case *trace:
+			le.Trace = fmt.Sprintf("projects/%s/traces/%s", v.projectID, v.traceID)
+			le.SpanId = v.spanID
+			le.TraceSampled = v.sampled
+			continue
+		case labels:
+			le.Labels = v
+			continue
+		case resourcePayload:
+			if le.Resource == nil {
+				le.Resource = &monitoredrespb.MonitoredResource{Type: v.typ, Labels: v.labels}
+			}
+			continue
+		}
+		f.AddTo(enc)
+	}
+
+	if st, err := structpb.NewStruct(enc.Fields); err == nil {
+		le.Payload = &loggingpb.LogEntry_JsonPayload{JsonPayload: st}
+	}
+
+	return le
+}
@@ -0,0 +1,56 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// LogHTTPRequest logs msg at lvl together with the HTTPRequest payload built from req and
+// res, via logger.Check so the payload (and the req/res body reads it requires) is only
+// built when lvl is enabled for logger.
+func LogHTTPRequest(logger *zap.Logger, lvl zapcore.Level, msg string, req *http.Request, res *http.Response, extra ...zap.Field) {
+	ce := logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(append([]zap.Field{zap.Object(HTTPRequestKey, NewHTTPRequest(req, res))}, extra...)...)
+}
+
+// LogOperation logs msg at lvl together with the Cloud Logging "operation" field, via
+// logger.Check so the payload is only built when lvl is enabled for logger.
+func LogOperation(logger *zap.Logger, lvl zapcore.Level, msg, id, producer string, first, last bool, extra ...zap.Field) {
+	ce := logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(append([]zap.Field{Operation(id, producer, first, last)}, extra...)...)
+}
+
+// LogSourceLocation logs msg at lvl together with the Cloud Logging "sourceLocation"
+// field, via logger.Check so the payload is only built when lvl is enabled for logger.
+func LogSourceLocation(logger *zap.Logger, lvl zapcore.Level, msg string, pc uintptr, file string, line int, ok bool, extra ...zap.Field) {
+	ce := logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(append([]zap.Field{SourceLocation(pc, file, line, ok)}, extra...)...)
+}
+
+// LogTrace logs msg at lvl together with the Cloud Logging trace correlation fields, via
+// logger.Check so the payload is only built when lvl is enabled for logger.
+func LogTrace(logger *zap.Logger, lvl zapcore.Level, msg, projectID, traceID, spanID string, sampled bool, extra ...zap.Field) {
+	ce := logger.Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+
+	ce.Write(append([]zap.Field{Trace(projectID, traceID, spanID, sampled)}, extra...)...)
+}
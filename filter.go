@@ -0,0 +1,233 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// Filter redacts a single string value, returning the value to keep in its place.
+type Filter func(value string) string
+
+// Delete returns a Filter that replaces the value with an empty string; the key itself is
+// still written.
+func Delete() Filter {
+	return func(string) string {
+		return ""
+	}
+}
+
+// Replace returns a Filter that substitutes value with s.
+func Replace(s string) Filter {
+	return func(string) string {
+		return s
+	}
+}
+
+// Hash returns a Filter that substitutes value with its hex-encoded SHA-256 digest.
+func Hash() Filter {
+	return func(value string) string {
+		sum := sha256.Sum256([]byte(value))
+		return fmt.Sprintf("%x", sum)
+	}
+}
+
+// IPMask returns a Filter that masks an IP address, keeping v4Bits of an IPv4 address or
+// v6Bits of an IPv6 address and zeroing the rest. Values that do not parse as an IP are
+// returned unchanged.
+func IPMask(v4Bits, v6Bits int) Filter {
+	return func(value string) string {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return value
+		}
+
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(v4Bits, 32)).String()
+		}
+
+		return ip.Mask(net.CIDRMask(v6Bits, 128)).String()
+	}
+}
+
+// QueryParamRedact returns a Filter that parses value as a URL and replaces each of the
+// given query parameters with "REDACTED". Values that do not parse as a URL are returned
+// unchanged.
+func QueryParamRedact(params ...string) Filter {
+	return func(value string) string {
+		u, err := url.Parse(value)
+		if err != nil {
+			return value
+		}
+
+		q := u.Query()
+		for _, p := range params {
+			if q.Has(p) {
+				q.Set(p, "REDACTED")
+			}
+		}
+		u.RawQuery = q.Encode()
+
+		return u.String()
+	}
+}
+
+// FilterEncoder wraps a zapcore.Encoder, applying per-field and per-header redaction
+// policies before the wrapped encoder serializes an entry.
+//
+// Construct one via WithFieldFilter/WithHeaderFilter passed to NewCore/WrapCore; it is not
+// intended to be used directly.
+type FilterEncoder struct {
+	zapcore.Encoder
+
+	fieldFilters  map[string]Filter
+	headerFilters map[string]Filter
+}
+
+var _ zapcore.Encoder = (*FilterEncoder)(nil)
+
+// newFilterEncoder wraps enc with fieldFilters/headerFilters. Either map may be nil.
+func newFilterEncoder(enc zapcore.Encoder, fieldFilters, headerFilters map[string]Filter) *FilterEncoder {
+	return &FilterEncoder{
+		Encoder:       enc,
+		fieldFilters:  fieldFilters,
+		headerFilters: headerFilters,
+	}
+}
+
+// Clone implements zapcore.Encoder.Clone.
+func (e *FilterEncoder) Clone() zapcore.Encoder {
+	return &FilterEncoder{
+		Encoder:       e.Encoder.Clone(),
+		fieldFilters:  e.fieldFilters,
+		headerFilters: e.headerFilters,
+	}
+}
+
+// EncodeEntry implements zapcore.Encoder.EncodeEntry.
+func (e *FilterEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = e.filterField(f)
+	}
+
+	return e.Encoder.EncodeEntry(ent, out)
+}
+
+// AddString implements zapcore.ObjectEncoder.AddString, applying fieldFilters to string
+// fields baked into the encoder (newCore's initial fields and logger.With) just as
+// EncodeEntry does for log-site fields.
+func (e *FilterEncoder) AddString(key, value string) {
+	if filter, ok := e.fieldFilters[normalizeFilterKey(key)]; ok {
+		value = filter(value)
+	}
+	e.Encoder.AddString(key, value)
+}
+
+// httpRequestFilterPrefix is the dotted-path prefix WithFieldFilter uses for nested
+// HTTPPayload fields (e.g. "http_request.requestUrl"), instead of the much longer
+// HTTPRequestKey ("logging.googleapis.com/httpRequest") f.Key actually carries.
+const httpRequestFilterPrefix = "http_request"
+
+// normalizeFilterKey canonicalizes a field-filter key path so casing/underscore
+// differences can't cause a silent no-op: HTTPPayload emits camelCase sub-keys
+// ("requestUrl"), but a hand-written keyPath is easy to get wrong as snake_case
+// ("request_url"). Folding both to the same form ("requesturl") makes either spelling
+// match.
+func normalizeFilterKey(keyPath string) string {
+	return strings.ToLower(strings.ReplaceAll(keyPath, "_", ""))
+}
+
+// filterField applies fieldFilters to f, keyed by f.Key, and recurses into nested
+// ObjectMarshaler fields so dotted paths like "http_request.requestUrl" (or
+// "http_request.request_url"; see normalizeFilterKey) reach the sub-key "requestUrl".
+// HTTPPayload gets the stable httpRequestFilterPrefix alias rather than its much longer
+// f.Key (HTTPRequestKey); other ObjectMarshaler fields are keyed by f.Key.
+func (e *FilterEncoder) filterField(f zapcore.Field) zapcore.Field {
+	if f.Type == zapcore.StringType {
+		if filter, ok := e.fieldFilters[normalizeFilterKey(f.Key)]; ok {
+			f.String = filter(f.String)
+		}
+	}
+
+	if f.Type == zapcore.ObjectMarshalerType {
+		if p, ok := f.Interface.(*HTTPPayload); ok {
+			clone := *p
+			clone.headerFilters = e.headerFilters
+			f.Interface = filteredMarshaler{ObjectMarshaler: &clone, prefix: httpRequestFilterPrefix, filters: e.fieldFilters}
+
+			return f
+		}
+		if m, ok := f.Interface.(zapcore.ObjectMarshaler); ok {
+			f.Interface = filteredMarshaler{ObjectMarshaler: m, prefix: f.Key, filters: e.fieldFilters}
+		}
+	}
+
+	return f
+}
+
+// filteredMarshaler wraps an ObjectMarshaler so field keys written by it are matched
+// against filters under "prefix.key".
+type filteredMarshaler struct {
+	zapcore.ObjectMarshaler
+
+	prefix  string
+	filters map[string]Filter
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.MarshalLogObject.
+func (m filteredMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	return m.ObjectMarshaler.MarshalLogObject(&filteringObjectEncoder{ObjectEncoder: enc, prefix: m.prefix, filters: m.filters})
+}
+
+// filteringObjectEncoder decorates a zapcore.ObjectEncoder, applying filters to string
+// values added under prefix.
+type filteringObjectEncoder struct {
+	zapcore.ObjectEncoder
+
+	prefix  string
+	filters map[string]Filter
+}
+
+// AddString implements zapcore.ObjectEncoder.AddString.
+func (o *filteringObjectEncoder) AddString(key, value string) {
+	if filter, ok := o.filters[normalizeFilterKey(o.prefix+"."+key)]; ok {
+		value = filter(value)
+	}
+	o.ObjectEncoder.AddString(key, value)
+}
+
+// WithFieldFilter registers a Filter applied to the string field at the dotted path
+// keyPath (e.g. "http_request.requestUrl" for a nested HTTPPayload field, or a bare key
+// such as "email" for a top-level one) before it is serialized. The entry's own message
+// is not a field and is not filtered. keyPath matching ignores
+// case and underscores, so "http_request.request_url" and "http_request.requestUrl" both
+// match the "requestUrl" sub-key HTTPPayload actually emits.
+func WithFieldFilter(keyPath string, filter Filter) Option {
+	return optionFunc(func(c *Core) {
+		if c.fieldFilters == nil {
+			c.fieldFilters = make(map[string]Filter)
+		}
+		c.fieldFilters[normalizeFilterKey(keyPath)] = filter
+	})
+}
+
+// WithHeaderFilter registers a Filter applied to the named HTTP header (matched
+// case-insensitively) recorded by HTTPPayload, e.g. WithHeaderFilter("Authorization",
+// Replace("REDACTED")).
+func WithHeaderFilter(header string, filter Filter) Option {
+	return optionFunc(func(c *Core) {
+		if c.headerFilters == nil {
+			c.headerFilters = make(map[string]Filter)
+		}
+		c.headerFilters[strings.ToLower(header)] = filter
+	})
+}
@@ -0,0 +1,148 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	logtypepb "google.golang.org/genproto/googleapis/logging/type"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// HTTPRequestKey is the HTTP request associated with the log entry, if any.
+	//
+	// httpRequest field:
+	// - https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#FIELDS.http_request
+	// - https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+	HTTPRequestKey = "logging.googleapis.com/httpRequest"
+)
+
+// HTTPPayload is the payload of the Cloud Logging httpRequest field.
+type HTTPPayload struct {
+	*logtypepb.HttpRequest
+
+	// header holds the request headers captured by NewHTTPRequest (req.Header as given),
+	// marshaled under a "headers" sub-object when non-empty. Equal ignores it, so the
+	// comparisons in TestHTTPRequestField see only the HttpRequest proto.
+	header http.Header
+
+	// headerFilters, when set by FilterEncoder, redacts values in header by lower-cased
+	// header name before they are marshaled.
+	headerFilters map[string]Filter
+}
+
+var _ zapcore.ObjectMarshaler = (*HTTPPayload)(nil)
+
+// Equal reports whether p and other carry equivalent HttpRequest payloads. It ignores the
+// header/headerFilters redaction metadata, letting go-cmp compare *HTTPPayload values
+// (e.g. in tests) without tripping over those unexported fields.
+func (p *HTTPPayload) Equal(other *HTTPPayload) bool {
+	if p == nil || other == nil {
+		return p == other
+	}
+
+	return proto.Equal(p.HttpRequest, other.HttpRequest)
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.MarshalLogObject.
+func (p *HTTPPayload) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("requestMethod", p.GetRequestMethod())
+	enc.AddString("requestUrl", p.GetRequestUrl())
+	enc.AddInt64("requestSize", p.GetRequestSize())
+	enc.AddInt32("status", p.GetStatus())
+	enc.AddInt64("responseSize", p.GetResponseSize())
+	enc.AddString("userAgent", p.GetUserAgent())
+	enc.AddString("remoteIp", p.GetRemoteIp())
+	enc.AddString("serverIp", p.GetServerIp())
+	enc.AddString("referer", p.GetReferer())
+	enc.AddString("protocol", p.GetProtocol())
+	enc.AddBool("cacheLookup", p.GetCacheLookup())
+	enc.AddBool("cacheHit", p.GetCacheHit())
+	enc.AddBool("cacheValidatedWithOriginServer", p.GetCacheValidatedWithOriginServer())
+	enc.AddInt64("cacheFillBytes", p.GetCacheFillBytes())
+	if d := p.GetLatency(); d != nil {
+		enc.AddString("latency", d.AsDuration().String())
+	}
+	if len(p.header) > 0 {
+		return enc.AddObject("headers", headerObject{header: p.header, filters: p.headerFilters})
+	}
+
+	return nil
+}
+
+// headerObject marshals an http.Header as a flat object, applying filters (keyed by
+// lower-cased header name) to redact sensitive values such as Authorization or Cookie.
+type headerObject struct {
+	header  http.Header
+	filters map[string]Filter
+}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.MarshalLogObject.
+func (h headerObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for k, v := range h.header {
+		value := strings.Join(v, ",")
+		if filter, ok := h.filters[strings.ToLower(k)]; ok {
+			value = filter(value)
+		}
+		enc.AddString(k, value)
+	}
+
+	return nil
+}
+
+// readBodySize drains rc to measure its length in bytes, returning a fresh ReadCloser with
+// the same content so that callers downstream of NewHTTPRequest can still read the body.
+func readBodySize(rc io.ReadCloser) (int64, io.ReadCloser) {
+	data, _ := io.ReadAll(rc)
+	rc.Close() //nolint:errcheck
+
+	return int64(len(data)), io.NopCloser(bytes.NewReader(data))
+}
+
+// NewHTTPRequest builds an HTTPPayload from req and/or res. Either argument may be nil.
+//
+// Reading req.Body/res.Body to measure RequestSize/ResponseSize replaces it with an
+// equivalent ReadCloser so the body remains readable by the rest of the handler chain.
+func NewHTTPRequest(req *http.Request, res *http.Response) *HTTPPayload {
+	p := &HTTPPayload{HttpRequest: &logtypepb.HttpRequest{}}
+
+	if req != nil {
+		p.RequestMethod = req.Method
+		p.UserAgent = req.Header.Get("User-Agent")
+		p.Referer = req.Header.Get("Referer")
+		p.RemoteIp = req.RemoteAddr
+		p.Protocol = req.Proto
+		p.header = req.Header
+		if req.URL != nil {
+			p.RequestUrl = req.URL.String()
+		}
+		if req.Body != nil {
+			var size int64
+			size, req.Body = readBodySize(req.Body)
+			p.RequestSize = size
+		}
+	}
+
+	if res != nil {
+		p.Status = int32(res.StatusCode)
+		if res.Body != nil {
+			var size int64
+			size, res.Body = readBodySize(res.Body)
+			p.ResponseSize = size
+		}
+	}
+
+	return p
+}
+
+// HTTPRequest adds the Cloud Logging "httpRequest" field built from req and res.
+func HTTPRequest(req *http.Request, res *http.Response) zapcore.Field {
+	return zap.Object(HTTPRequestKey, NewHTTPRequest(req, res))
+}
@@ -0,0 +1,214 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// MiddlewareOption configures Middleware.
+type MiddlewareOption interface {
+	apply(*middlewareConfig)
+}
+
+// middlewareOptionFunc wraps a func so it satisfies the MiddlewareOption interface.
+type middlewareOptionFunc func(*middlewareConfig)
+
+func (f middlewareOptionFunc) apply(c *middlewareConfig) {
+	f(c)
+}
+
+type middlewareConfig struct {
+	projectID          string
+	skipPaths          map[string]struct{}
+	requestBodyLogger  func(*http.Request, []byte)
+	responseBodyLogger func(*http.Request, int, []byte)
+}
+
+// WithProjectID sets the Cloud project id used to build the trace field from the incoming
+// "X-Cloud-Trace-Context" header. It is unnecessary if the trace header is absent.
+func WithProjectID(projectID string) MiddlewareOption {
+	return middlewareOptionFunc(func(c *middlewareConfig) {
+		c.projectID = projectID
+	})
+}
+
+// WithSkipPaths excludes the given request paths (e.g. health checks) from logging.
+func WithSkipPaths(paths ...string) MiddlewareOption {
+	return middlewareOptionFunc(func(c *middlewareConfig) {
+		for _, p := range paths {
+			c.skipPaths[p] = struct{}{}
+		}
+	})
+}
+
+// WithRequestBodyLogger registers a hook invoked with the request body captured for each
+// logged request, for a request-reproducer style workflow.
+func WithRequestBodyLogger(fn func(req *http.Request, body []byte)) MiddlewareOption {
+	return middlewareOptionFunc(func(c *middlewareConfig) {
+		c.requestBodyLogger = fn
+	})
+}
+
+// WithResponseBodyLogger registers a hook invoked with the response status and body
+// captured for each logged request.
+func WithResponseBodyLogger(fn func(req *http.Request, status int, body []byte)) MiddlewareOption {
+	return middlewareOptionFunc(func(c *middlewareConfig) {
+		c.responseBodyLogger = fn
+	})
+}
+
+// readAndRestoreBody drains rc and returns its content alongside a fresh ReadCloser with
+// the same bytes, so the body remains readable by the rest of the handler chain.
+func readAndRestoreBody(rc io.ReadCloser) ([]byte, io.ReadCloser) {
+	data, _ := io.ReadAll(rc)
+	rc.Close() //nolint:errcheck
+
+	return data, io.NopCloser(bytes.NewReader(data))
+}
+
+// levelForStatus chooses the log level for an HTTP status code: 5xx logs as Error, 4xx as
+// Warn, everything else as Info.
+func levelForStatus(status int) zapcore.Level {
+	switch {
+	case status >= http.StatusInternalServerError:
+		return zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		return zapcore.WarnLevel
+	default:
+		return zapcore.InfoLevel
+	}
+}
+
+// responseWriter wraps an http.ResponseWriter to capture the status code, bytes written,
+// and (when a ResponseBodyLogger is configured) a copy of the response body.
+type responseWriter struct {
+	http.ResponseWriter
+
+	status      int
+	wroteHeader bool
+	bytes       int
+	body        []byte
+	captureBody bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	if w.captureBody {
+		w.body = append(w.body, p[:n]...)
+	}
+
+	return n, err
+}
+
+// Flush implements http.Flusher, forwarding to the wrapped ResponseWriter so streaming
+// handlers (e.g. SSE) keep working behind Middleware.
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, forwarding to the wrapped ResponseWriter so WebSocket
+// handlers keep working behind Middleware.
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("zapcl: underlying ResponseWriter does not implement http.Hijacker")
+	}
+
+	return h.Hijack()
+}
+
+// Middleware returns net/http middleware that logs one HTTPRequest entry per request to
+// logger, with Latency measured around the wrapped handler.
+//
+// It composes with Gin/Echo via their respective http.Handler adapters (gin.WrapH,
+// echo.WrapHandler), so no framework-specific variant is needed.
+func Middleware(logger *zap.Logger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := &middlewareConfig{skipPaths: make(map[string]struct{})}
+	for _, opt := range opts {
+		opt.apply(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, skip := cfg.skipPaths[r.URL.Path]; skip {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+
+			// Capture the request body (and its size) before next.ServeHTTP runs, since the
+			// handler may drain or close r.Body, leaving nothing for NewHTTPRequest to
+			// measure afterwards.
+			var reqBody []byte
+			var reqSize int64
+			if r.Body != nil {
+				reqBody, r.Body = readAndRestoreBody(r.Body)
+				reqSize = int64(len(reqBody))
+			}
+
+			rw := &responseWriter{ResponseWriter: w, status: http.StatusOK, captureBody: cfg.responseBodyLogger != nil}
+			next.ServeHTTP(rw, r)
+
+			latency := time.Since(start)
+			res := &http.Response{StatusCode: rw.status, Header: rw.Header()}
+			payload := NewHTTPRequest(r, res)
+			payload.RequestSize = reqSize
+			payload.Latency = durationpb.New(latency)
+			payload.ResponseSize = int64(rw.bytes)
+			payload.CacheValidatedWithOriginServer = rw.status == http.StatusNotModified
+			if addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr); ok {
+				payload.ServerIp = addr.String()
+			}
+			// X-Cache is the de facto convention CDNs/reverse proxies (Varnish, Fastly,
+			// CloudFront) use to report cache status; there is no standard header for it.
+			if xcache := rw.Header().Get("X-Cache"); xcache != "" {
+				payload.CacheLookup = true
+				payload.CacheHit = strings.Contains(strings.ToUpper(xcache), "HIT")
+			}
+
+			fields := []zap.Field{zap.Object(HTTPRequestKey, payload)}
+			if header := r.Header.Get("X-Cloud-Trace-Context"); header != "" {
+				fields = append(fields, TraceFromHeader(cfg.projectID, header))
+			}
+
+			if cfg.requestBodyLogger != nil {
+				cfg.requestBodyLogger(r, reqBody)
+			}
+			if cfg.responseBodyLogger != nil {
+				cfg.responseBodyLogger(r, rw.status, rw.body)
+			}
+
+			if ce := logger.Check(levelForStatus(rw.status), r.URL.Path); ce != nil {
+				ce.Write(fields...)
+			}
+		})
+	}
+}
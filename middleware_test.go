@@ -0,0 +1,75 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// TestMiddlewareRequestSize guards against NewHTTPRequest measuring r.Body after the
+// handler has already drained it: Middleware must capture RequestSize before calling
+// next.ServeHTTP.
+func TestMiddlewareRequestSize(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body) //nolint:errcheck
+		r.Body.Close()     //nolint:errcheck
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("12345"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+
+	payload, ok := entries[0].ContextMap()[HTTPRequestKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("entry missing %s field", HTTPRequestKey)
+	}
+	if got, want := payload["requestSize"], int64(5); got != want {
+		t.Errorf("requestSize = %v, want %v", got, want)
+	}
+}
+
+// TestMiddlewareCacheValidatedWithOriginServer checks a 304 response is reported as
+// validated with the origin server.
+func TestMiddlewareCacheValidatedWithOriginServer(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(core)
+
+	handler := Middleware(logger)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	entries := logs.TakeAll()
+	if len(entries) != 1 {
+		t.Fatalf("got %d log entries, want 1", len(entries))
+	}
+
+	payload, ok := entries[0].ContextMap()[HTTPRequestKey].(map[string]interface{})
+	if !ok {
+		t.Fatalf("entry missing %s field", HTTPRequestKey)
+	}
+	if got, want := payload["cacheValidatedWithOriginServer"], true; got != want {
+		t.Errorf("cacheValidatedWithOriginServer = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2022 The zapcl Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package zapcl
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	oteltrace "go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/zchee/zapcl/pkg/monitoredresource"
+)
+
+const (
+	// TraceKey is the resource name of the trace that this log entry belongs to, formatted
+	// as "projects/PROJECT_ID/traces/TRACE_ID".
+	//
+	// trace field:
+	// - https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#FIELDS.trace
+	// - https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+	TraceKey = "logging.googleapis.com/trace"
+
+	// SpanIDKey is the span ID within the trace associated with the log entry, encoded as a
+	// 16-character hexadecimal string.
+	//
+	// spanId field: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#FIELDS.span_id
+	SpanIDKey = "logging.googleapis.com/spanId"
+
+	// TraceSampledKey reports whether the trace referenced by TraceKey was sampled.
+	//
+	// trace_sampled field: https://cloud.google.com/logging/docs/reference/v2/rest/v2/LogEntry#FIELDS.trace_sampled
+	TraceSampledKey = "logging.googleapis.com/trace_sampled"
+)
+
+// trace is the payload of the Cloud Logging trace correlation fields.
+//
+// It is added via zap.Inline so that TraceKey, SpanIDKey and TraceSampledKey land at the
+// top level of the log entry rather than nested under a single object key.
+type trace struct {
+	projectID string
+	traceID   string
+	spanID    string
+	sampled   bool
+}
+
+var _ zapcore.ObjectMarshaler = (*trace)(nil)
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.MarshalLogObject.
+func (t *trace) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString(TraceKey, fmt.Sprintf("projects/%s/traces/%s", t.projectID, t.traceID))
+	enc.AddString(SpanIDKey, t.spanID)
+	enc.AddBool(TraceSampledKey, t.sampled)
+
+	return nil
+}
+
+// Trace adds the Cloud Logging trace correlation fields so that entries link to the Cloud
+// Trace span identified by traceID/spanID.
+//
+// projectID is used to build the "projects/PROJECT_ID/traces/TRACE_ID" resource name
+// required by TraceKey.
+func Trace(projectID, traceID, spanID string, sampled bool) zapcore.Field {
+	return zap.Inline(&trace{
+		projectID: projectID,
+		traceID:   traceID,
+		spanID:    spanID,
+		sampled:   sampled,
+	})
+}
+
+// TraceFromContext returns the Cloud Logging trace correlation fields for the OpenTelemetry
+// span stored in ctx.
+//
+// It reports zap.Skip if ctx carries no valid span context. The project id is taken from
+// monitoredresource.Detect, matching the resource this package already attaches to every
+// entry.
+func TraceFromContext(ctx context.Context) zapcore.Field {
+	sc := oteltrace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return zap.Skip()
+	}
+
+	return Trace(monitoredresource.Detect().ProjectID, sc.TraceID().String(), sc.SpanID().String(), sc.IsSampled())
+}
+
+// TraceFromHeader parses an incoming "X-Cloud-Trace-Context" header, formatted as
+// "TRACE_ID/SPAN_ID;o=OPTIONS" per the propagation.HTTPFormat convention, and returns the
+// Cloud Logging trace correlation fields for it.
+//
+// It reports zap.Skip if header is empty or malformed.
+func TraceFromHeader(projectID, header string) zapcore.Field {
+	traceID, spanID, sampled, ok := parseCloudTraceContext(header)
+	if !ok {
+		return zap.Skip()
+	}
+
+	return Trace(projectID, traceID, spanID, sampled)
+}
+
+// parseCloudTraceContext parses the "TRACE_ID/SPAN_ID;o=OPTIONS" format used by the
+// "X-Cloud-Trace-Context" header, converting the decimal span id into the 16-character
+// hexadecimal form Cloud Logging expects for SpanIDKey.
+func parseCloudTraceContext(header string) (traceID, spanID string, sampled, ok bool) {
+	slash := strings.IndexByte(header, '/')
+	if slash < 0 {
+		return "", "", false, false
+	}
+
+	traceID, rest := header[:slash], header[slash+1:]
+	spanID = rest
+	if semi := strings.IndexByte(rest, ';'); semi >= 0 {
+		spanID = rest[:semi]
+		if opts := rest[semi+1:]; strings.HasPrefix(opts, "o=") {
+			sampled = opts[len("o="):] == "1"
+		}
+	}
+
+	if traceID == "" || spanID == "" {
+		return "", "", false, false
+	}
+
+	if id, err := strconv.ParseUint(spanID, 10, 64); err == nil {
+		spanID = fmt.Sprintf("%016x", id)
+	}
+
+	return traceID, spanID, sampled, true
+}
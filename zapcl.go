@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"runtime/debug"
 	"sort"
 
 	"go.uber.org/zap"
@@ -63,21 +64,40 @@ type Core struct {
 
 	enc        zapcore.Encoder
 	ws         zapcore.WriteSyncer
-	fields     []zapcore.Field
 	initFields map[string]interface{}
+	initLabels map[string]string
+
+	// persistFields mirrors what addFields bakes into enc: the detected monitored
+	// resource, WithInitialFields, and every logger.With(...) field. The entryWriter fast
+	// path bypasses enc entirely, so Write prepends persistFields to each entry's fields
+	// instead, keeping the two paths' output in sync.
+	persistFields []zapcore.Field
+
+	fieldFilters  map[string]Filter
+	headerFilters map[string]Filter
+
+	errorReporting bool
+	erService      string
+	erVersion      string
 }
 
 var _ zapcore.Core = (*Core)(nil)
 
+// clone copies the Core. The cloned encoder already carries the fields baked in by
+// newCore and With, so Write never has to re-add them per entry.
 func (c *Core) clone() *Core {
-	newCore := &Core{
-		fields: make([]zapcore.Field, len(c.fields)),
-		enc:    c.enc.Clone(),
-		ws:     c.ws,
+	return &Core{
+		LevelEnabler:   c.LevelEnabler,
+		enc:            c.enc.Clone(),
+		ws:             c.ws,
+		errorReporting: c.errorReporting,
+		erService:      c.erService,
+		erVersion:      c.erVersion,
+		initLabels:     c.initLabels,
+		persistFields:  c.persistFields,
+		fieldFilters:   c.fieldFilters,
+		headerFilters:  c.headerFilters,
 	}
-	copy(newCore.fields, c.fields)
-
-	return newCore
 }
 
 func addFields(enc zapcore.ObjectEncoder, fields []zapcore.Field) {
@@ -86,6 +106,50 @@ func addFields(enc zapcore.ObjectEncoder, fields []zapcore.Field) {
 	}
 }
 
+// resourceKey is the persistFields field key carrying the detected monitored resource;
+// entryFromFields type-switches on the resourcePayload value itself, so the key only
+// matters if it ever falls through to enc unhandled.
+const resourceKey = "__zapcl_resource__"
+
+// resourcePayload carries the monitored resource's Type and flattened Labels to the
+// entryWriter fast path, so apiwritesyncer.go's entryFromFields can translate it into
+// LogEntry.Resource the same way WithResource does explicitly.
+type resourcePayload struct {
+	typ    string
+	labels map[string]string
+}
+
+var _ zapcore.ObjectMarshaler = resourcePayload{}
+
+// MarshalLogObject implements zapcore.ObjectMarshaler.MarshalLogObject, so resourcePayload
+// degrades to plain fields if it is ever encoded directly instead of being special-cased.
+func (r resourcePayload) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(r.labels))
+	for k := range r.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		enc.AddString(k, r.labels[k])
+	}
+
+	return nil
+}
+
+// stringLabels keeps only the string-valued entries of fields, matching the
+// map<string, string> LogEntry.Resource.Labels expects.
+func stringLabels(fields map[string]interface{}) map[string]string {
+	labels := make(map[string]string, len(fields))
+	for k, v := range fields {
+		if s, ok := v.(string); ok {
+			labels[k] = s
+		}
+	}
+
+	return labels
+}
+
 // With adds structured context to the Core.
 //
 // With implements zapcore.Core.With.
@@ -93,6 +157,10 @@ func (c *Core) With(fields []zapcore.Field) zapcore.Core {
 	clone := c.clone()
 	addFields(clone.enc, fields)
 
+	clone.persistFields = make([]zapcore.Field, 0, len(c.persistFields)+len(fields))
+	clone.persistFields = append(clone.persistFields, c.persistFields...)
+	clone.persistFields = append(clone.persistFields, fields...)
+
 	return clone
 }
 
@@ -110,24 +178,66 @@ func (c *Core) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.Check
 	return ce
 }
 
+// entryWriter is implemented by WriteSyncers that can consume a zapcore.Entry and its
+// Fields directly, such as the LogEntry built by NewAPIWriteSyncer. Core.Write prefers it
+// over the JSON encoder to avoid encoding an entry twice.
+type entryWriter interface {
+	WriteEntry(ent zapcore.Entry, fields []zapcore.Field) error
+}
+
 // Write serializes the Entry and any Fields supplied at the log site and
 // writes them to their destination.
 //
 // Write implemenns zapcore.Core.Write.
 func (c *Core) Write(ent zapcore.Entry, fields []zapcore.Field) error {
-	for _, field := range c.fields {
-		field.AddTo(c.enc)
+	if len(c.initLabels) > 0 {
+		fields = mergeLabels(fields, c.initLabels)
 	}
 
-	buf, err := c.enc.EncodeEntry(ent, fields)
-	if err != nil {
-		return fmt.Errorf("could not encode entry: %w", err)
+	if c.errorReporting && ent.Level >= zapcore.ErrorLevel {
+		fields = append(fields,
+			zap.String("@type", errorReportingType),
+			zap.Object("serviceContext", erServiceContext{c.erService, c.erVersion}),
+			zap.Object("context", erContext{loc: newSource(ent.Caller.PC, ent.Caller.File, ent.Caller.Line, ent.Caller.Defined)}),
+		)
+		ent.Message += "\n" + string(debug.Stack())
 	}
 
-	_, err = c.ws.Write(buf.Bytes())
-	buf.Free()
-	if err != nil {
-		return fmt.Errorf("could not write buf: %w", err)
+	if ew, ok := c.ws.(entryWriter); ok {
+		// entryWriter bypasses enc, so prepend persistFields (the resource/initFields/
+		// With(...) context enc already carries for the JSON path) and apply
+		// WithFieldFilter redaction to top-level string fields directly, since enc's
+		// FilterEncoder never runs here either. Nested paths such as
+		// "http_request.requestUrl" are not filtered on this path.
+		entryFields := make([]zapcore.Field, 0, len(c.persistFields)+len(fields))
+		entryFields = append(entryFields, c.persistFields...)
+		entryFields = append(entryFields, fields...)
+
+		if len(c.fieldFilters) > 0 {
+			for i, f := range entryFields {
+				if f.Type == zapcore.StringType {
+					if filter, ok := c.fieldFilters[normalizeFilterKey(f.Key)]; ok {
+						f.String = filter(f.String)
+						entryFields[i] = f
+					}
+				}
+			}
+		}
+
+		if err := ew.WriteEntry(ent, entryFields); err != nil {
+			return fmt.Errorf("could not write entry: %w", err)
+		}
+	} else {
+		buf, err := c.enc.EncodeEntry(ent, fields)
+		if err != nil {
+			return fmt.Errorf("could not encode entry: %w", err)
+		}
+
+		_, err = c.ws.Write(buf.Bytes())
+		buf.Free()
+		if err != nil {
+			return fmt.Errorf("could not write buf: %w", err)
+		}
 	}
 
 	if ent.Level > zapcore.ErrorLevel {
@@ -212,15 +322,28 @@ func newCore(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler, opts ...Option)
 		opt.apply(core)
 	}
 
+	// handling WithFieldFilter/WithHeaderFilter options
+	if len(core.fieldFilters) > 0 || len(core.headerFilters) > 0 {
+		core.enc = newFilterEncoder(core.enc, core.fieldFilters, core.headerFilters)
+	}
+
 	res := monitoredresource.Detect()
-	core.fields = []zapcore.Field{
+	resFields := zapcore.NewMapObjectEncoder()
+	_ = res.MarshalLogObject(resFields) // same fields zap.Inline(res) below flattens
+
+	baseFields := []zapcore.Field{
 		zap.String(res.Type, res.LogID),
 		zap.Inline(res),
 	}
+	// persistFields carries the same resource info to the entryWriter fast path, which
+	// bypasses enc; resourcePayload keeps it as a translatable Type/Labels pair instead of
+	// the flattened top-level fields baseFields bakes into enc.
+	persistFields := []zapcore.Field{
+		zap.Object(resourceKey, resourcePayload{typ: res.Type, labels: stringLabels(resFields.Fields)}),
+	}
 
 	// handling initFields option
 	if len(core.initFields) > 0 {
-		fs := make([]zapcore.Field, 0, len(core.initFields))
 		keys := make([]string, 0, len(core.initFields))
 		for k := range core.initFields {
 			keys = append(keys, k)
@@ -228,26 +351,33 @@ func newCore(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler, opts ...Option)
 		sort.Strings(keys)
 
 		for _, k := range keys {
-			fs = append(fs, zap.Any(k, core.initFields[k]))
+			baseFields = append(baseFields, zap.Any(k, core.initFields[k]))
+			persistFields = append(persistFields, zap.Any(k, core.initFields[k]))
 		}
-		core.fields = append(core.fields, fs...)
 	}
 
+	// Bake the resource/initFields fields into the encoder once, instead of re-adding
+	// them to every entry in Write.
+	addFields(core.enc, baseFields)
+	core.persistFields = persistFields
+
 	return core
 }
 
 // NewCore creates a Core that writes logs to a WriteSyncer.
+//
+// The returned zapcore.Core is the *Core itself, not zapcore.NewCore's built-in
+// implementation, so options that depend on Core.Write (WithErrorReporting,
+// WithInitialLabels) and the entryWriter fast path take effect.
 func NewCore(ws zapcore.WriteSyncer, enab zapcore.LevelEnabler, opts ...Option) zapcore.Core {
-	core := newCore(ws, enab, opts...)
-
-	return zapcore.NewCore(core.enc, core.ws, core.LevelEnabler)
+	return newCore(ws, enab, opts...)
 }
 
 // WrapCore wraps or replaces the Logger's underlying zapcore.Core.
+//
+// The returned zapcore.Core is the *Core itself; see NewCore.
 func WrapCore(opts ...Option) zap.Option {
 	return zap.WrapCore(func(c zapcore.Core) zapcore.Core {
-		core := newCore(nopWriteSyncer{}, c, opts...)
-
-		return zapcore.NewCore(core.enc, core.ws, core.LevelEnabler)
+		return newCore(nopWriteSyncer{}, c, opts...)
 	})
 }